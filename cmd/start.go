@@ -15,8 +15,16 @@
 package cmd
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"net/http"
 
@@ -32,7 +40,6 @@ import (
 	"github.com/wpengine/lostromos/version"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -55,16 +62,23 @@ func init() {
 	startCmd.Flags().String("crd-version", "v1", "the version of the CRD you want monitored")
 	startCmd.Flags().String("crd-namespace", metav1.NamespaceNone, "(optional) the namespace of the CRD you want monitored, only needed for namespaced CRDs (ex: default)")
 	startCmd.Flags().String("crd-filter", "", "(optional) Annotation key to specify that the custom resource has opted in to watching by Lostromos")
+	startCmd.Flags().Duration("crd-resync", 0, "(optional) How often existing custom resources are resynced and passed to the controller as updates, 0 disables resyncing (ex: 5m)")
+	startCmd.Flags().String("crd-label-selector", "", "(optional) Label selector to restrict which custom resources are watched by Lostromos (ex: managed-by=team-a)")
 	startCmd.Flags().String("helm-chart", "", "Path for helm chart")
-	startCmd.Flags().String("helm-ns", "default", "Namespace for resources deployed by helm")
+	startCmd.Flags().String("helm-ns", "", "Namespace for resources deployed by helm. Defaults to $POD_NAMESPACE if set, otherwise \"default\"")
 	startCmd.Flags().String("helm-prefix", "lostromos", "Prefix for release names in helm")
 	startCmd.Flags().String("helm-tiller", "tiller-deploy:44134", "Address for helm tiller")
 	startCmd.Flags().Bool("helm-wait", false, "Use the helm --wait flag for creating and updating releases")
 	startCmd.Flags().Int64("helm-wait-timeout", 120, "The time in seconds to wait for kubernetes resources to be created when doing a helm install or upgrade")
 	startCmd.Flags().String("kube-config", filepath.Join(homeDir(), ".kube", "config"), "absolute path to the kubeconfig file. Only required if running outside-of-cluster.")
+	startCmd.Flags().String("kube-ca-file", "", "(optional) Path to a CA bundle used to verify the kubernetes API server, overriding the kubeconfig or in-cluster CA")
+	startCmd.Flags().String("kube-cert-file", "", "(optional) Path to a client certificate for authenticating to the kubernetes API server, requires --kube-key-file")
+	startCmd.Flags().String("kube-key-file", "", "(optional) Path to the key for --kube-cert-file")
+	startCmd.Flags().Bool("kube-insecure-skip-tls-verify", false, "(optional) Skip verifying the kubernetes API server certificate. Insecure, for testing only")
 	startCmd.Flags().Bool("nop", false, "nop")
 	startCmd.Flags().String("server-address", ":8080", "The address and port for endpoints such as /metrics and /status")
 	startCmd.Flags().String("metrics-endpoint", "/metrics", "The URI for the metrics endpoint")
+	startCmd.Flags().Duration("shutdown-timeout", 2*time.Minute, "How long to wait on SIGINT/SIGTERM for events that are being handled to finish before exiting")
 	startCmd.Flags().String("status-endpoint", "/status", "The URI for the status endpoint")
 	startCmd.Flags().String("templates", "", "absolute path to the directory with your template files")
 
@@ -73,6 +87,8 @@ func init() {
 	viperBindFlag("crd.version", startCmd.Flags().Lookup("crd-version"))
 	viperBindFlag("crd.namespace", startCmd.Flags().Lookup("crd-namespace"))
 	viperBindFlag("crd.filter", startCmd.Flags().Lookup("crd-filter"))
+	viperBindFlag("crd.resync", startCmd.Flags().Lookup("crd-resync"))
+	viperBindFlag("crd.labelSelector", startCmd.Flags().Lookup("crd-label-selector"))
 	viperBindFlag("helm.chart", startCmd.Flags().Lookup("helm-chart"))
 	viperBindFlag("helm.namespace", startCmd.Flags().Lookup("helm-ns"))
 	viperBindFlag("helm.releasePrefix", startCmd.Flags().Lookup("helm-prefix"))
@@ -80,9 +96,14 @@ func init() {
 	viperBindFlag("helm.wait", startCmd.Flags().Lookup("helm-wait"))
 	viperBindFlag("helm.waitTimeout", startCmd.Flags().Lookup("helm-wait-timeout"))
 	viperBindFlag("k8s.config", startCmd.Flags().Lookup("kube-config"))
+	viperBindFlag("k8s.caFile", startCmd.Flags().Lookup("kube-ca-file"))
+	viperBindFlag("k8s.certFile", startCmd.Flags().Lookup("kube-cert-file"))
+	viperBindFlag("k8s.keyFile", startCmd.Flags().Lookup("kube-key-file"))
+	viperBindFlag("k8s.insecureSkipTLSVerify", startCmd.Flags().Lookup("kube-insecure-skip-tls-verify"))
 	viperBindFlag("nop", startCmd.Flags().Lookup("nop"))
 	viperBindFlag("server.address", startCmd.Flags().Lookup("server-address"))
 	viperBindFlag("server.metricsEndpoint", startCmd.Flags().Lookup("metrics-endpoint"))
+	viperBindFlag("shutdownTimeout", startCmd.Flags().Lookup("shutdown-timeout"))
 	viperBindFlag("server.statusEndpoint", startCmd.Flags().Lookup("status-endpoint"))
 	viperBindFlag("templates", startCmd.Flags().Lookup("templates"))
 }
@@ -104,19 +125,72 @@ func getKubeClient() (*restclient.Config, error) {
 	cfg, err = restclient.InClusterConfig()
 	if err == nil {
 		viper.Set("k8s.config", "")
-		return cfg, err
+	} else {
+		cfg, err = clientcmd.BuildConfigFromFlags("", viper.GetString("k8s.config"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyTLSOptions(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyTLSOptions overrides the TLS settings of the kubernetes client config with any given through the k8s.caFile,
+// k8s.certFile, k8s.keyFile and k8s.insecureSkipTLSVerify options, checking that the files exist and parse.
+func applyTLSOptions(cfg *restclient.Config) error {
+	caFile := viper.GetString("k8s.caFile")
+	certFile := viper.GetString("k8s.certFile")
+	keyFile := viper.GetString("k8s.keyFile")
+	insecure := viper.GetBool("k8s.insecureSkipTLSVerify")
+
+	if caFile != "" && insecure {
+		return errors.New("kube-ca-file and kube-insecure-skip-tls-verify cannot be used together")
+	}
+	if (certFile == "") != (keyFile == "") {
+		return errors.New("kube-cert-file and kube-key-file must be provided together")
 	}
 
-	return clientcmd.BuildConfigFromFlags("", viper.GetString("k8s.config"))
+	if caFile != "" {
+		ca, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read kube-ca-file: %v", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(ca) {
+			return fmt.Errorf("kube-ca-file %q does not contain any PEM encoded certificates", caFile)
+		}
+		cfg.TLSClientConfig.CAFile = caFile
+		cfg.TLSClientConfig.CAData = nil
+	}
+	if certFile != "" {
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return fmt.Errorf("failed to load kube-cert-file and kube-key-file: %v", err)
+		}
+		cfg.TLSClientConfig.CertFile = certFile
+		cfg.TLSClientConfig.CertData = nil
+		cfg.TLSClientConfig.KeyFile = keyFile
+		cfg.TLSClientConfig.KeyData = nil
+	}
+	if insecure {
+		// The kubernetes client refuses a CA together with insecure, so drop any CA from the kubeconfig
+		cfg.TLSClientConfig.Insecure = true
+		cfg.TLSClientConfig.CAFile = ""
+		cfg.TLSClientConfig.CAData = nil
+	}
+	return nil
 }
 
 func buildCRWatcher(cfg *restclient.Config) (*crwatcher.CRWatcher, error) {
 	cwCfg := &crwatcher.Config{
-		PluralName: viper.GetString("crd.name"),
-		Group:      viper.GetString("crd.group"),
-		Version:    viper.GetString("crd.version"),
-		Namespace:  viper.GetString("crd.namespace"),
-		Filter:     viper.GetString("crd.filter"),
+		PluralName:    viper.GetString("crd.name"),
+		Group:         viper.GetString("crd.group"),
+		Version:       viper.GetString("crd.version"),
+		Namespace:     viper.GetString("crd.namespace"),
+		Filter:        viper.GetString("crd.filter"),
+		LabelSelector: viper.GetString("crd.labelSelector"),
+		Resync:        viper.GetDuration("crd.resync"),
 	}
 	ctlr := getController()
 	l := &crLogger{logger: logger}
@@ -138,15 +212,16 @@ func getController() crwatcher.ResourceController {
 		hw := viper.GetBool("helm.wait")
 		hwto := viper.GetInt64("helm.waitTimeout")
 		logger = logger.With("controller", "helm")
+		c := helmctlr.NewController(chrt, hns, hrn, ht, hw, hwto, logger)
 		logger.Infow("using helm controller for deployment",
 			"helmChart", chrt,
-			"helmNamespace", hns,
+			"helmNamespace", c.Namespace,
 			"helmReleasePrefix", hrn,
 			"helmTiller", ht,
 			"helmWait", hw,
 			"helmWaitTimeout", hwto,
 		)
-		return helmctlr.NewController(chrt, hns, hrn, ht, hw, hwto, logger)
+		return c
 	}
 	logger = logger.With("controller", "template")
 	logger.Infow("using template controller for deployment", "templateDir", viper.GetString("templates"))
@@ -161,6 +236,10 @@ func (c crLogger) Error(err error) {
 	c.logger.Errorw("kubernetes error", "error", err)
 }
 
+func (c crLogger) Debug(msg string, keysAndValues ...interface{}) {
+	c.logger.Debugw(msg, keysAndValues...)
+}
+
 func validateOptions() error {
 	if viper.GetString("crd.name") == "" {
 		return errors.New("crd-name is a required parameter")
@@ -192,7 +271,7 @@ func startServer() error {
 
 	// Set up Prometheus and Status endpoints.
 	http.Handle(viper.GetString("server.metricsEndpoint"), promhttp.Handler())
-	http.HandleFunc(viper.GetString("server.statusEndpoint"), status.Handler)
+	http.HandleFunc(viper.GetString("server.statusEndpoint"), status.NewHandler(crw.Healthy))
 	go func() {
 		err := http.ListenAndServe(viper.GetString("server.address"), nil)
 		if err != nil {
@@ -200,5 +279,20 @@ func startServer() error {
 		}
 	}()
 
-	return crw.Watch(wait.NeverStop)
+	stopCh := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- crw.Watch(stopCh)
+	}()
+
+	select {
+	case err := <-watchErr:
+		return err
+	case sig := <-signals:
+		logger.Infow("received signal, shutting down", "signal", sig.String())
+	}
+	close(stopCh)
+	return crw.Shutdown(viper.GetDuration("shutdownTimeout"))
 }
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/wpengine/lostromos/helmctlr"
@@ -50,17 +51,96 @@ func TestGetKubeClientForKubeConfigReturnsWhenFileExists(t *testing.T) {
 	assert.Equal(t, "https://localhost:8443", cfg.Host)
 }
 
+// setTLSOptions sets the k8s TLS options for a test and returns a func clearing them again.
+func setTLSOptions(caFile, certFile, keyFile string, insecure bool) func() {
+	viper.Set("k8s.config", path.Join("..", "test", "data", "kubeconfig"))
+	viper.Set("k8s.caFile", caFile)
+	viper.Set("k8s.certFile", certFile)
+	viper.Set("k8s.keyFile", keyFile)
+	viper.Set("k8s.insecureSkipTLSVerify", insecure)
+	return func() {
+		viper.Set("k8s.caFile", "")
+		viper.Set("k8s.certFile", "")
+		viper.Set("k8s.keyFile", "")
+		viper.Set("k8s.insecureSkipTLSVerify", false)
+	}
+}
+
+func TestGetKubeClientAppliesTLSOptions(t *testing.T) {
+	caFile := path.Join("..", "test", "data", "ca.crt")
+	certFile := path.Join("..", "test", "data", "client.crt")
+	keyFile := path.Join("..", "test", "data", "client.key")
+	defer setTLSOptions(caFile, certFile, keyFile, false)()
+
+	cfg, err := getKubeClient()
+	assert.Nil(t, err)
+	if assert.NotNil(t, cfg) {
+		assert.Equal(t, caFile, cfg.TLSClientConfig.CAFile)
+		assert.Equal(t, certFile, cfg.TLSClientConfig.CertFile)
+		assert.Equal(t, keyFile, cfg.TLSClientConfig.KeyFile)
+		assert.False(t, cfg.TLSClientConfig.Insecure)
+	}
+}
+
+func TestGetKubeClientInsecureSkipTLSVerifyDropsCA(t *testing.T) {
+	defer setTLSOptions("", "", "", true)()
+
+	cfg, err := getKubeClient()
+	assert.Nil(t, err)
+	if assert.NotNil(t, cfg) {
+		assert.True(t, cfg.TLSClientConfig.Insecure)
+		assert.Equal(t, "", cfg.TLSClientConfig.CAFile)
+		assert.Nil(t, cfg.TLSClientConfig.CAData)
+	}
+}
+
+func TestGetKubeClientRejectsInvalidTLSOptions(t *testing.T) {
+	caFile := path.Join("..", "test", "data", "ca.crt")
+	certFile := path.Join("..", "test", "data", "client.crt")
+	keyFile := path.Join("..", "test", "data", "client.key")
+	var testCases = []struct {
+		name     string
+		caFile   string
+		certFile string
+		keyFile  string
+		insecure bool
+		err      string
+	}{
+		{"Test fails when the CA file doesn't exist", "/i-dont-exist/ca.crt", "", "", false, "failed to read kube-ca-file"},
+		{"Test fails when the CA file has no certificates", keyFile, "", "", false, "does not contain any PEM encoded certificates"},
+		{"Test fails with a CA file and insecure", caFile, "", "", true, "cannot be used together"},
+		{"Test fails with a cert but no key", "", certFile, "", false, "must be provided together"},
+		{"Test fails with a key but no cert", "", "", keyFile, false, "must be provided together"},
+		{"Test fails when the cert file doesn't exist", "", "/i-dont-exist/client.crt", keyFile, false, "failed to load kube-cert-file"},
+		{"Test fails when the cert and key don't match", "", caFile, keyFile, false, "failed to load kube-cert-file"},
+	}
+
+	for _, tt := range testCases {
+		reset := setTLSOptions(tt.caFile, tt.certFile, tt.keyFile, tt.insecure)
+		cfg, err := getKubeClient()
+		reset()
+		assert.Nil(t, cfg, tt.name)
+		if assert.NotNil(t, err, fmt.Sprintf("Test: %s, should return an error", tt.name)) {
+			assert.Contains(t, err.Error(), tt.err, tt.name)
+		}
+	}
+}
+
 func TestBuildCRWatcherReturnsProperlyConfiguredWatcher(t *testing.T) {
 	crdGroup := "test.lostromos.k8s"
-	crdName := "testCRD"
+	crdName := "testcrds"
 	crdNamespace := "lostromos"
 	crdVersion := "v9876"
 	crdFilter := "useThisResource"
+	crdLabelSelector := "managed-by=lostromos"
+	crdResync := 5 * time.Minute
 	viper.Set("crd.group", crdGroup)
 	viper.Set("crd.name", crdName)
 	viper.Set("crd.namespace", crdNamespace)
 	viper.Set("crd.version", crdVersion)
 	viper.Set("crd.filter", crdFilter)
+	viper.Set("crd.labelSelector", crdLabelSelector)
+	viper.Set("crd.resync", crdResync)
 
 	kubeCfg := &restclient.Config{}
 	crw, err := buildCRWatcher(kubeCfg)
@@ -71,6 +151,8 @@ func TestBuildCRWatcherReturnsProperlyConfiguredWatcher(t *testing.T) {
 	assert.Equal(t, crdNamespace, crw.Config.Namespace)
 	assert.Equal(t, crdVersion, crw.Config.Version)
 	assert.Equal(t, crdFilter, crw.Config.Filter)
+	assert.Equal(t, crdLabelSelector, crw.Config.LabelSelector)
+	assert.Equal(t, crdResync, crw.Config.Resync)
 }
 
 func TestGetControllerReturnsHelmController(t *testing.T) {
@@ -16,27 +16,38 @@ package crwatcher
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/wpengine/lostromos/metrics"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 )
 
+// PausedAnnotation is the annotation that, when set to "true" on a resource, stops adds and updates for it being
+// passed to the controller until it is removed
+const PausedAnnotation = "lostromos.wpengine.io/paused"
+
 // Config provides config for a CRD Watcher
 type Config struct {
-	Group      string        // API Group of the CRD
-	Namespace  string        // namespace of the CRD
-	Version    string        // version of the CRD
-	PluralName string        // plural name of the CRD
-	Filter     string        // Optional disregard resources that don't have an annotation key matching this filter
-	Resync     time.Duration // How often existing CRs should be resynced (marked as updated)
+	Group         string        // API Group of the CRD
+	Namespace     string        // namespace of the CRD
+	Version       string        // version of the CRD
+	PluralName    string        // plural name of the CRD
+	Filter        string        // Optional disregard resources that don't have an annotation key matching this filter
+	LabelSelector string        // Optional label selector, only resources with matching labels are watched
+	Resync        time.Duration // How often existing CRs should be resynced (marked as updated)
 }
 
 // CRWatcher thing that watches
@@ -47,6 +58,14 @@ type CRWatcher struct {
 	store      cache.Store
 	controller cache.Controller
 	logger     ErrorLogger
+
+	mu           sync.Mutex     // guards shuttingDown and additions to inFlight
+	shuttingDown bool           // set by Shutdown, no further events are passed to the controller
+	inFlight     sync.WaitGroup // events currently being handled by the controller
+
+	// skippedAdds holds the keys of resources whose add was skipped because they were paused, so they are sent as
+	// ResourceAdded once resumed. It is only touched from the event handlers, which the informer runs one at a time.
+	skippedAdds map[string]bool
 }
 
 // ResourceController exposes the functionality of a controller that
@@ -61,9 +80,9 @@ type CRWatcher struct {
 //      happens, and it will get called even if nothing changed. This is useful
 //      for periodically evaluating or syncing something.
 //  * ResourceDeleted will get the final state of the item if it is known,
-//      otherwise it will get an object of type DeletedFinalStateUnknown. This
-//      can happen if the watch is closed and misses the delete event and we
-//      don't notice the deletion until the subsequent re-list.
+//      otherwise it will get the last state that was observed before the
+//      deletion. This can happen if the watch is closed and misses the delete
+//      event and we don't notice the deletion until the subsequent re-list.
 type ResourceController interface {
 	ResourceAdded(resource *unstructured.Unstructured)
 	ResourceUpdated(oldResource, newResource *unstructured.Unstructured)
@@ -75,8 +94,18 @@ type ErrorLogger interface {
 	Error(err error)
 }
 
+// DebugLogger may optionally be implemented by an ErrorLogger to also receive debug messages from the watcher, such
+// as a resource being skipped because it is paused
+type DebugLogger interface {
+	Debug(msg string, keysAndValues ...interface{})
+}
+
 // NewCRWatcher builds a CRWatcher
 func NewCRWatcher(cfg *Config, kubeCfg *restclient.Config, rc ResourceController, l ErrorLogger) (*CRWatcher, error) {
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
 	cw := &CRWatcher{
 		Config: cfg,
 		logger: l,
@@ -99,6 +128,33 @@ func NewCRWatcher(cfg *Config, kubeCfg *restclient.Config, rc ResourceController
 	return cw, nil
 }
 
+// validateConfig checks that the CRD group, version, and plural name are well formed, so a typo fails at startup
+// rather than as an obscure error from the kubernetes client once we start watching.
+func validateConfig(cfg *Config) error {
+	if cfg.Version == "" {
+		return errors.New("the CRD version is required")
+	}
+	if errs := validation.IsDNS1035Label(cfg.Version); len(errs) > 0 {
+		return fmt.Errorf("invalid CRD version %q: %s", cfg.Version, strings.Join(errs, ", "))
+	}
+	if cfg.PluralName == "" {
+		return errors.New("the CRD plural name is required")
+	}
+	if errs := validation.IsDNS1035Label(cfg.PluralName); len(errs) > 0 {
+		return fmt.Errorf("invalid CRD plural name %q: %s", cfg.PluralName, strings.Join(errs, ", "))
+	}
+	if cfg.Group == "" {
+		return errors.New("the CRD group is required")
+	}
+	if errs := validation.IsDNS1123Subdomain(cfg.Group); len(errs) > 0 {
+		return fmt.Errorf("invalid CRD group %q: %s", cfg.Group, strings.Join(errs, ", "))
+	}
+	if _, err := labels.Parse(cfg.LabelSelector); err != nil {
+		return fmt.Errorf("invalid label selector %q: %v", cfg.LabelSelector, err)
+	}
+	return nil
+}
+
 func (cw *CRWatcher) setupRuntimeLogging() {
 	if cw.logger != nil {
 		utilruntime.ErrorHandlers = []func(error){
@@ -114,25 +170,116 @@ func (cw *CRWatcher) logKubeError(err error) {
 func (cw *CRWatcher) setupHandler(con ResourceController) {
 	cw.handler = cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			r := obj.(*unstructured.Unstructured)
-			if cw.passesFiltering(r) {
-				con.ResourceAdded(r)
+			if !cw.startHandling() {
+				return
+			}
+			defer cw.inFlight.Done()
+			r, ok := cw.toResource(obj)
+			if !ok {
+				return
+			}
+			defer cw.recoverHandler(r)
+			if !cw.passesFiltering(r) {
+				return
+			}
+			if isPaused(r) {
+				cw.skipAdd(r)
+				return
 			}
+			con.ResourceAdded(r)
 		},
 		DeleteFunc: func(obj interface{}) {
-			r := obj.(*unstructured.Unstructured)
+			if !cw.startHandling() {
+				return
+			}
+			defer cw.inFlight.Done()
+			// If the watch missed the delete we get a tombstone holding the last known state of the resource.
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			r, ok := cw.toResource(obj)
+			if !ok {
+				return
+			}
+			defer cw.recoverHandler(r)
+			if cw.resumeAdd(r) {
+				cw.debug("skipping delete of paused resource that was never added", r)
+				return
+			}
 			if cw.passesFiltering(r) {
 				con.ResourceDeleted(r)
 			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			oldR := oldObj.(*unstructured.Unstructured)
-			newR := newObj.(*unstructured.Unstructured)
+			if !cw.startHandling() {
+				return
+			}
+			defer cw.inFlight.Done()
+			oldR, ok := cw.toResource(oldObj)
+			if !ok {
+				return
+			}
+			newR, ok := cw.toResource(newObj)
+			if !ok {
+				return
+			}
+			defer cw.recoverHandler(newR)
+			if isPaused(newR) {
+				cw.updatePaused(con, oldR, newR)
+				return
+			}
+			if cw.resumeAdd(newR) {
+				if cw.passesFiltering(newR) {
+					con.ResourceAdded(newR)
+				}
+				return
+			}
 			cw.update(con, oldR, newR)
 		},
 	}
 }
 
+// startHandling registers an event as in flight, returning false if the watcher is shutting down and the event
+// should be dropped. The caller must call cw.inFlight.Done() once the event has been handled.
+func (cw *CRWatcher) startHandling() bool {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	if cw.shuttingDown {
+		return false
+	}
+	cw.inFlight.Add(1)
+	return true
+}
+
+// toResource converts an object received from the informer into an unstructured resource. Anything else is logged
+// and skipped rather than allowed to panic the handler.
+func (cw *CRWatcher) toResource(obj interface{}) (*unstructured.Unstructured, bool) {
+	r, ok := obj.(*unstructured.Unstructured)
+	if !ok || r == nil {
+		if cw.logger != nil {
+			cw.logger.Error(fmt.Errorf("unexpected object type %T received from watch", obj))
+		}
+		return nil, false
+	}
+	return r, true
+}
+
+// recoverHandler is deferred by each event handler so a panic in the controller is logged and counted instead of
+// taking down the whole process. The event itself is not retried. If Config.Resync is set, a resource whose add or
+// update panicked is handed to the controller again on the next resync, but always as ResourceUpdated, never as
+// ResourceAdded. A resource whose delete panicked is already gone from the store, so a resync never hands it back
+// and its teardown is lost.
+func (cw *CRWatcher) recoverHandler(r *unstructured.Unstructured) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+	metrics.ReconcilePanics.Inc()
+	if cw.logger != nil {
+		cw.logger.Error(fmt.Errorf("recovered from panic while handling resource %s/%s: %v", r.GetNamespace(), r.GetName(), rec))
+	}
+}
+
 // update sends an appropriate notification to the controller based on filtering outcomes of the old and new state of a
 // resource.
 //
@@ -162,19 +309,30 @@ func (cw *CRWatcher) setupResource(dc *dynamic.Client) {
 }
 
 func (cw *CRWatcher) setupController() {
+	cw.store, cw.controller = cache.NewInformer(
+		cw.listWatch(),
+		&unstructured.Unstructured{},
+		cw.Config.Resync,
+		cw.handler,
+	)
+}
+
+// listWatch builds the ListWatch for the informer, restricting both calls to the configured label selector if one
+// was given.
+func (cw *CRWatcher) listWatch() *cache.ListWatch {
 	listFunc := func(opts metav1.ListOptions) (runtime.Object, error) {
+		if cw.Config.LabelSelector != "" {
+			opts.LabelSelector = cw.Config.LabelSelector
+		}
 		return cw.resource.List(opts)
 	}
 	watchFunc := func(opts metav1.ListOptions) (watch.Interface, error) {
+		if cw.Config.LabelSelector != "" {
+			opts.LabelSelector = cw.Config.LabelSelector
+		}
 		return cw.resource.Watch(opts)
 	}
-	lw := &cache.ListWatch{ListFunc: listFunc, WatchFunc: watchFunc}
-	cw.store, cw.controller = cache.NewInformer(
-		lw,
-		&unstructured.Unstructured{},
-		cw.Config.Resync,
-		cw.handler,
-	)
+	return &cache.ListWatch{ListFunc: listFunc, WatchFunc: watchFunc}
 }
 
 // passesFiltering checks to see if we are using an opt in filter (if not, then return true), and if so returns whether we
@@ -193,6 +351,73 @@ func (cw *CRWatcher) passesFiltering(r *unstructured.Unstructured) bool {
 	return ok
 }
 
+// isPaused returns whether the resource has the PausedAnnotation set to "true".
+func isPaused(r *unstructured.Unstructured) bool {
+	return r.GetAnnotations()[PausedAnnotation] == "true"
+}
+
+// updatePaused handles an update to a resource whose new state is paused. The update itself is skipped, with two
+// exceptions so the controller's view stays consistent once the resource is resumed:
+//
+// If the new state passes filtering and the old state does not, the resource was never added, so it is tracked the
+// same way as a paused add.
+// If the old state passes filtering and the new state does not, the resource is deleted unless it was never added,
+// otherwise its workload would be left behind.
+//
+func (cw *CRWatcher) updatePaused(con ResourceController, oldR *unstructured.Unstructured, newR *unstructured.Unstructured) {
+	newPasses := cw.passesFiltering(newR)
+	if !newPasses && cw.resumeAdd(newR) {
+		return
+	}
+	if cw.skippedAdds[resourceKey(newR)] {
+		cw.debug("skipping paused resource", newR)
+		return
+	}
+	oldPasses := cw.passesFiltering(oldR)
+	switch {
+	case newPasses && !oldPasses:
+		cw.skipAdd(newR)
+	case oldPasses && !newPasses:
+		con.ResourceDeleted(oldR)
+	case newPasses:
+		cw.debug("skipping paused resource", newR)
+	}
+}
+
+// skipAdd records that the add for a paused resource was skipped.
+func (cw *CRWatcher) skipAdd(r *unstructured.Unstructured) {
+	if cw.skippedAdds == nil {
+		cw.skippedAdds = make(map[string]bool)
+	}
+	cw.skippedAdds[resourceKey(r)] = true
+	cw.debug("skipping paused resource", r)
+}
+
+// resumeAdd forgets a resource whose add was skipped, returning whether it had been skipped.
+func (cw *CRWatcher) resumeAdd(r *unstructured.Unstructured) bool {
+	key := resourceKey(r)
+	if !cw.skippedAdds[key] {
+		return false
+	}
+	delete(cw.skippedAdds, key)
+	return true
+}
+
+// resourceKey returns the namespace/name key for a resource, or just the name for a cluster scoped resource.
+func resourceKey(r *unstructured.Unstructured) string {
+	if r.GetNamespace() == "" {
+		return r.GetName()
+	}
+	return r.GetNamespace() + "/" + r.GetName()
+}
+
+// debug logs msg for the resource if the logger implements DebugLogger.
+func (cw *CRWatcher) debug(msg string, r *unstructured.Unstructured) {
+	if l, ok := cw.logger.(DebugLogger); ok {
+		l.Debug(msg, "resource", r.GetName(), "namespace", r.GetNamespace())
+	}
+}
+
 // Watch will be called to begin watching the configured custom resource. All
 // events will be passed back to the ResourceController
 func (cw *CRWatcher) Watch(stopCh <-chan struct{}) error {
@@ -202,3 +427,31 @@ func (cw *CRWatcher) Watch(stopCh <-chan struct{}) error {
 	cw.controller.Run(stopCh)
 	return nil
 }
+
+// Healthy checks that the CRD can still be listed from the Kubernetes API, returning the error if it can't. It only
+// asks for a single resource so it stays cheap to call from a status endpoint.
+func (cw *CRWatcher) Healthy() error {
+	_, err := cw.resource.List(metav1.ListOptions{Limit: 1})
+	return err
+}
+
+// Shutdown stops any further events from being passed to the controller and waits up to timeout for the events
+// already being handled to finish, so an in-flight apply or release isn't cut off mid-operation. It should be called
+// after closing the stop channel given to Watch.
+func (cw *CRWatcher) Shutdown(timeout time.Duration) error {
+	cw.mu.Lock()
+	cw.shuttingDown = true
+	cw.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		cw.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s waiting for in-flight events to finish", timeout)
+	}
+}
@@ -18,13 +18,20 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/wpengine/lostromos/printctlr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 )
 
 type logResult struct {
@@ -39,9 +46,13 @@ func (c testLogger) Error(err error) {
 	c.res.msg = fmt.Sprintf("error: %s", err)
 }
 
+func (c testLogger) Debug(msg string, keysAndValues ...interface{}) {
+	c.res.msg = fmt.Sprintf("debug: %s %v", msg, keysAndValues)
+}
+
 func TestNewCRWatcher(t *testing.T) {
 	kubeCfg := &restclient.Config{}
-	cfg := &Config{PluralName: "test"}
+	cfg := &Config{Group: "stable.wpengine.io", PluralName: "test", Version: "v1"}
 
 	cw, err := NewCRWatcher(cfg, kubeCfg, printctlr.Controller{}, testLogger{})
 
@@ -57,7 +68,7 @@ func TestNewCRWatcher(t *testing.T) {
 func TestNewCRWatcherReturnsNilOnError(t *testing.T) {
 	kubeCfg := &restclient.Config{}
 	kubeCfg.Host = "http:///"
-	cfg := &Config{PluralName: "test"}
+	cfg := &Config{Group: "stable.wpengine.io", PluralName: "test", Version: "v1"}
 
 	cw, err := NewCRWatcher(cfg, kubeCfg, printctlr.Controller{}, testLogger{})
 
@@ -66,9 +77,120 @@ func TestNewCRWatcherReturnsNilOnError(t *testing.T) {
 	assert.Equal(t, "host must be a URL or a host:port pair: \"http:///\"", err.Error())
 }
 
+func TestNewCRWatcherReturnsErrorOnInvalidLabelSelector(t *testing.T) {
+	kubeCfg := &restclient.Config{}
+	cfg := &Config{Group: "stable.wpengine.io", PluralName: "test", Version: "v1", LabelSelector: "managed-by in (team-a"}
+
+	cw, err := NewCRWatcher(cfg, kubeCfg, printctlr.Controller{}, testLogger{})
+
+	assert.Nil(t, cw)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid label selector \"managed-by in (team-a\"")
+	}
+}
+
+// fakeResource records the options passed to List and Watch, returns items (or listErr) from List and, if set, events sent through
+// watcher from Watch. Any other call will panic on the nil embedded interface.
+type fakeResource struct {
+	dynamic.ResourceInterface
+	items     []unstructured.Unstructured
+	watcher   *watch.FakeWatcher
+	listOpts  metav1.ListOptions
+	watchOpts metav1.ListOptions
+	listErr   error
+}
+
+func (f *fakeResource) List(opts metav1.ListOptions) (runtime.Object, error) {
+	f.listOpts = opts
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	return &unstructured.UnstructuredList{Items: f.items}, nil
+}
+
+func (f *fakeResource) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	f.watchOpts = opts
+	if f.watcher != nil {
+		return f.watcher, nil
+	}
+	return watch.NewFake(), nil
+}
+
+func TestSetupControllerUsesLabelSelector(t *testing.T) {
+	res := &fakeResource{}
+	cw := &CRWatcher{
+		Config:   &Config{LabelSelector: "managed-by=team-a"},
+		resource: res,
+	}
+	cw.setupController()
+	assert.NotNil(t, cw.controller)
+
+	lw := cw.listWatch()
+	_, err := lw.List(metav1.ListOptions{ResourceVersion: "1"})
+	assert.Nil(t, err)
+	_, err = lw.Watch(metav1.ListOptions{ResourceVersion: "2"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, metav1.ListOptions{ResourceVersion: "1", LabelSelector: "managed-by=team-a"}, res.listOpts)
+	assert.Equal(t, metav1.ListOptions{ResourceVersion: "2", LabelSelector: "managed-by=team-a"}, res.watchOpts)
+}
+
+func TestSetupControllerWithoutLabelSelectorLeavesOptionsUntouched(t *testing.T) {
+	res := &fakeResource{}
+	cw := &CRWatcher{
+		Config:   &Config{},
+		resource: res,
+	}
+	cw.setupController()
+	assert.NotNil(t, cw.controller)
+
+	lw := cw.listWatch()
+	listOpts := metav1.ListOptions{ResourceVersion: "1", LabelSelector: "from=caller"}
+	watchOpts := metav1.ListOptions{ResourceVersion: "2"}
+	_, err := lw.List(listOpts)
+	assert.Nil(t, err)
+	_, err = lw.Watch(watchOpts)
+	assert.Nil(t, err)
+
+	assert.Equal(t, listOpts, res.listOpts)
+	assert.Equal(t, watchOpts, res.watchOpts)
+}
+
+func TestNewCRWatcherValidatesConfig(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		group      string
+		version    string
+		pluralName string
+		err        string
+	}{
+		{"Test succeeds with a valid group, version and plural name", "stable.wpengine.io", "v1", "users", ""},
+		{"Test fails without a group", "", "v1", "users", "the CRD group is required"},
+		{"Test fails without a version", "stable.wpengine.io", "", "users", "the CRD version is required"},
+		{"Test fails with an invalid version", "stable.wpengine.io", "v1/beta", "users", "invalid CRD version \"v1/beta\""},
+		{"Test fails without a plural name", "stable.wpengine.io", "v1", "", "the CRD plural name is required"},
+		{"Test fails with an invalid plural name", "stable.wpengine.io", "v1", "Users", "invalid CRD plural name \"Users\""},
+		{"Test fails with an invalid group", "stable_wpengine.io", "v1", "users", "invalid CRD group \"stable_wpengine.io\""},
+	}
+
+	for _, tt := range testCases {
+		cfg := &Config{Group: tt.group, Version: tt.version, PluralName: tt.pluralName}
+		cw, err := NewCRWatcher(cfg, &restclient.Config{}, printctlr.Controller{}, testLogger{})
+		if tt.err == "" {
+			assert.Nil(t, err, fmt.Sprintf("Test: %s, should not return an error. Error: %s", tt.name, err))
+			assert.NotNil(t, cw, tt.name)
+		} else {
+			assert.Nil(t, cw, tt.name)
+			if assert.NotNil(t, err, fmt.Sprintf("Test: %s, should return an error", tt.name)) {
+				assert.Contains(t, err.Error(), tt.err, tt.name)
+			}
+		}
+	}
+}
+
 func TestLogKubeError(t *testing.T) {
 	kubeCfg := &restclient.Config{}
-	cfg := &Config{PluralName: "test"}
+	cfg := &Config{Group: "stable.wpengine.io", PluralName: "test", Version: "v1"}
 	res := &logResult{}
 	lgr := &testLogger{res: res}
 	cw, err := NewCRWatcher(cfg, kubeCfg, printctlr.Controller{}, lgr)
@@ -199,6 +321,57 @@ func TestSetupHandlerDeleteFuncUsesFilter(t *testing.T) {
 	cw.handler.OnDelete(r2)
 }
 
+// Test to ensure that a tombstone for a missed delete is unwrapped and passed on to ResourceDeleted.
+func TestSetupHandlerDeleteFuncUnwrapsTombstone(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRC := NewMockResourceController(mockCtrl)
+	cw := &CRWatcher{
+		Config: &Config{},
+	}
+	r := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "Thing1",
+			},
+		},
+	}
+	cw.setupHandler(mockRC)
+
+	mockRC.EXPECT().ResourceDeleted(r)
+
+	cw.handler.OnDelete(cache.DeletedFinalStateUnknown{Key: "Thing1", Obj: r})
+}
+
+// Test to ensure that objects which aren't unstructured resources are logged and skipped instead of panicking.
+func TestSetupHandlerSkipsUnexpectedTypes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRC := NewMockResourceController(mockCtrl)
+	res := &logResult{}
+	cw := &CRWatcher{
+		Config: &Config{},
+		logger: &testLogger{res: res},
+	}
+	r := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "Thing1",
+			},
+		},
+	}
+	cw.setupHandler(mockRC)
+
+	assert.NotPanics(t, func() { cw.handler.OnAdd("Thing1") })
+	assert.Equal(t, "error: unexpected object type string received from watch", res.msg)
+	assert.NotPanics(t, func() { cw.handler.OnUpdate(r, 1) })
+	assert.Equal(t, "error: unexpected object type int received from watch", res.msg)
+	assert.NotPanics(t, func() { cw.handler.OnDelete(cache.DeletedFinalStateUnknown{Key: "Thing1"}) })
+	assert.Equal(t, "error: unexpected object type <nil> received from watch", res.msg)
+}
+
 func TestSetupHandlerUpdateFunc(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -287,9 +460,422 @@ func TestSetupHandlerUpdateFuncUsesFilter(t *testing.T) {
 	cw.handler.OnUpdate(r1Filtered, r2Filtered)
 }
 
+func getPromCounterValue(metric string) float64 {
+	mf, _ := prometheus.DefaultGatherer.Gather()
+	for _, s := range mf {
+		if s.GetName() == metric {
+			return s.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+type panicController struct{}
+
+func (p panicController) ResourceAdded(r *unstructured.Unstructured) {
+	panic("added")
+}
+
+func (p panicController) ResourceUpdated(oldR, newR *unstructured.Unstructured) {
+	panic("updated")
+}
+
+func (p panicController) ResourceDeleted(r *unstructured.Unstructured) {
+	panic("deleted")
+}
+
+// Test to ensure a panic in the controller is recovered and logged with the identity of the resource being handled.
+func TestSetupHandlerRecoversFromPanic(t *testing.T) {
+	res := &logResult{}
+	cw := &CRWatcher{
+		Config: &Config{},
+		logger: &testLogger{res: res},
+	}
+	r := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      "Thing1",
+				"namespace": "default",
+			},
+		},
+	}
+	cw.setupHandler(panicController{})
+
+	panics := getPromCounterValue("releases_panic_total")
+	assert.NotPanics(t, func() { cw.handler.OnAdd(r) })
+	assert.Equal(t, "error: recovered from panic while handling resource default/Thing1: added", res.msg)
+	assert.Equal(t, panics+1, getPromCounterValue("releases_panic_total"))
+
+	panics = getPromCounterValue("releases_panic_total")
+	assert.NotPanics(t, func() { cw.handler.OnUpdate(r, r) })
+	assert.Equal(t, "error: recovered from panic while handling resource default/Thing1: updated", res.msg)
+	assert.Equal(t, panics+1, getPromCounterValue("releases_panic_total"))
+
+	panics = getPromCounterValue("releases_panic_total")
+	assert.NotPanics(t, func() { cw.handler.OnDelete(r) })
+	assert.Equal(t, "error: recovered from panic while handling resource default/Thing1: deleted", res.msg)
+	assert.Equal(t, panics+1, getPromCounterValue("releases_panic_total"))
+}
+
+// pausedResources returns the same resource unpaused and paused.
+func pausedResources() (*unstructured.Unstructured, *unstructured.Unstructured) {
+	r := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      "Thing1",
+				"namespace": "default",
+			},
+		},
+	}
+	rPaused := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      "Thing1",
+				"namespace": "default",
+				"annotations": map[string]interface{}{
+					"lostromos.wpengine.io/paused": "true",
+				},
+			},
+		},
+	}
+	return r, rPaused
+}
+
+// Test to ensure that updates for a paused resource are skipped, that removing the annotation resumes updates, and
+// that deletes are still passed on so the resource can be cleaned up.
+func TestSetupHandlerSkipsPausedResources(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRC := NewMockResourceController(mockCtrl)
+	res := &logResult{}
+	cw := &CRWatcher{
+		Config: &Config{},
+		logger: &testLogger{res: res},
+	}
+	r, rPaused := pausedResources()
+	cw.setupHandler(mockRC)
+
+	gomock.InOrder(
+		mockRC.EXPECT().ResourceAdded(r),
+		mockRC.EXPECT().ResourceUpdated(rPaused, r),
+		mockRC.EXPECT().ResourceDeleted(rPaused),
+	)
+	mockRC.EXPECT().ResourceUpdated(r, rPaused).MinTimes(0).MaxTimes(0)
+	mockRC.EXPECT().ResourceUpdated(rPaused, rPaused).MinTimes(0).MaxTimes(0)
+
+	cw.handler.OnAdd(r)
+	cw.handler.OnUpdate(r, rPaused)
+	assert.Equal(t, "debug: skipping paused resource [resource Thing1 namespace default]", res.msg)
+	cw.handler.OnUpdate(rPaused, rPaused)
+	cw.handler.OnUpdate(rPaused, r)
+	cw.handler.OnUpdate(r, rPaused)
+	cw.handler.OnDelete(rPaused)
+}
+
+// Test to ensure a resource created paused is sent as ResourceAdded, not ResourceUpdated, once it is resumed.
+func TestSetupHandlerResumesPausedAddAsAdd(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRC := NewMockResourceController(mockCtrl)
+	res := &logResult{}
+	cw := &CRWatcher{
+		Config: &Config{},
+		logger: &testLogger{res: res},
+	}
+	r, rPaused := pausedResources()
+	cw.setupHandler(mockRC)
+
+	gomock.InOrder(
+		mockRC.EXPECT().ResourceAdded(r),
+		mockRC.EXPECT().ResourceUpdated(r, r),
+	)
+	mockRC.EXPECT().ResourceAdded(rPaused).MinTimes(0).MaxTimes(0)
+	mockRC.EXPECT().ResourceUpdated(rPaused, r).MinTimes(0).MaxTimes(0)
+
+	cw.handler.OnAdd(rPaused)
+	assert.Equal(t, "debug: skipping paused resource [resource Thing1 namespace default]", res.msg)
+	assert.True(t, cw.skippedAdds["default/Thing1"])
+	cw.handler.OnUpdate(rPaused, rPaused)
+	cw.handler.OnUpdate(rPaused, r)
+	assert.Equal(t, 0, len(cw.skippedAdds))
+	cw.handler.OnUpdate(r, r)
+}
+
+// Test to ensure deleting a resource that was created paused and never resumed doesn't send ResourceDeleted, since
+// nothing was ever deployed for it.
+func TestSetupHandlerSkipsDeleteOfPausedAdd(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRC := NewMockResourceController(mockCtrl)
+	res := &logResult{}
+	cw := &CRWatcher{
+		Config: &Config{},
+		logger: &testLogger{res: res},
+	}
+	_, rPaused := pausedResources()
+	cw.setupHandler(mockRC)
+
+	mockRC.EXPECT().ResourceAdded(rPaused).MinTimes(0).MaxTimes(0)
+	mockRC.EXPECT().ResourceDeleted(rPaused).MinTimes(0).MaxTimes(0)
+
+	cw.handler.OnAdd(rPaused)
+	cw.handler.OnDelete(cache.DeletedFinalStateUnknown{Key: "default/Thing1", Obj: rPaused})
+	assert.Equal(t, "debug: skipping delete of paused resource that was never added [resource Thing1 namespace default]", res.msg)
+	assert.Equal(t, 0, len(cw.skippedAdds))
+}
+
+// Test to ensure filter transitions while paused keep the controller consistent: a resource filtered in while paused
+// is added once resumed, and a deployed resource filtered out while paused is still deleted.
+func TestSetupHandlerPausedFilterTransitions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRC := NewMockResourceController(mockCtrl)
+	cw := &CRWatcher{
+		Config: &Config{Filter: "useThisResource"},
+	}
+	rFiltered := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "Thing1",
+				"annotations": map[string]interface{}{
+					"useThisResource": "true",
+				},
+			},
+		},
+	}
+	rPaused := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "Thing1",
+				"annotations": map[string]interface{}{
+					"lostromos.wpengine.io/paused": "true",
+				},
+			},
+		},
+	}
+	rFilteredPaused := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "Thing1",
+				"annotations": map[string]interface{}{
+					"useThisResource":              "true",
+					"lostromos.wpengine.io/paused": "true",
+				},
+			},
+		},
+	}
+	cw.setupHandler(mockRC)
+
+	gomock.InOrder(
+		mockRC.EXPECT().ResourceAdded(rFiltered),
+		mockRC.EXPECT().ResourceDeleted(rFilteredPaused),
+	)
+
+	// Filtered in while paused, then resumed.
+	cw.handler.OnAdd(rPaused)
+	cw.handler.OnUpdate(rPaused, rFilteredPaused)
+	assert.True(t, cw.skippedAdds["Thing1"])
+	cw.handler.OnUpdate(rFilteredPaused, rFiltered)
+
+	// Paused, then filtered out while paused.
+	cw.handler.OnUpdate(rFiltered, rFilteredPaused)
+	cw.handler.OnUpdate(rFilteredPaused, rPaused)
+	assert.Equal(t, 0, len(cw.skippedAdds))
+}
+
+// Test to ensure the paused annotation only pauses a resource when it is set to "true".
+func TestIsPausedRequiresTrue(t *testing.T) {
+	r := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "Thing1",
+				"annotations": map[string]interface{}{
+					"lostromos.wpengine.io/paused": "false",
+				},
+			},
+		},
+	}
+
+	assert.False(t, isPaused(r))
+	r.SetAnnotations(map[string]string{"lostromos.wpengine.io/paused": "true"})
+	assert.True(t, isPaused(r))
+	r.SetAnnotations(nil)
+	assert.False(t, isPaused(r))
+}
+
+// resyncController records the name of every resource it is handed and then panics.
+type resyncController struct {
+	added   chan string
+	updated chan string
+	deleted chan string
+}
+
+func newResyncController() *resyncController {
+	return &resyncController{
+		added:   make(chan string, 1000),
+		updated: make(chan string, 1000),
+		deleted: make(chan string, 1000),
+	}
+}
+
+func (c *resyncController) ResourceAdded(r *unstructured.Unstructured) {
+	c.added <- r.GetName()
+	panic("added")
+}
+
+func (c *resyncController) ResourceUpdated(oldR, newR *unstructured.Unstructured) {
+	c.updated <- newR.GetName()
+	panic("updated")
+}
+
+func (c *resyncController) ResourceDeleted(r *unstructured.Unstructured) {
+	c.deleted <- r.GetName()
+	panic("deleted")
+}
+
+func receive(t *testing.T, ch chan string, what string) string {
+	select {
+	case name := <-ch:
+		return name
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+		return ""
+	}
+}
+
+func drain(ch chan string) {
+	for {
+		select {
+		case <-ch:
+		default:
+			return
+		}
+	}
+}
+
+// Test to show what a resync hands back after a panic. A panicked add comes back as an update, never as another add,
+// and a panicked delete never comes back at all.
+func TestResyncAfterPanic(t *testing.T) {
+	r := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      "Thing1",
+				"namespace": "default",
+			},
+		},
+	}
+	res := &fakeResource{items: []unstructured.Unstructured{r}, watcher: watch.NewFake()}
+	con := newResyncController()
+	cw := &CRWatcher{
+		Config:   &Config{Resync: 10 * time.Millisecond},
+		resource: res,
+	}
+	cw.setupHandler(con)
+	cw.setupController()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go cw.controller.Run(stopCh)
+
+	assert.Equal(t, "Thing1", receive(t, con.added, "the initial add"))
+	assert.Equal(t, "Thing1", receive(t, con.updated, "the resync after the add panicked"))
+	assert.Equal(t, 0, len(con.added), "a panicked add should not be retried as an add")
+
+	go res.watcher.Delete(&unstructured.Unstructured{Object: r.Object})
+	assert.Equal(t, "Thing1", receive(t, con.deleted, "the delete"))
+	drain(con.updated)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, len(con.deleted), "a panicked delete should never be retried")
+	assert.Equal(t, 0, len(con.updated), "a deleted resource should not be resynced")
+	assert.Equal(t, 0, len(con.added), "a deleted resource should not be resynced")
+}
+
 func TestWatchReturnsErrorIfNotSetup(t *testing.T) {
 	cw := &CRWatcher{}
 	err := cw.Watch(wait.NeverStop)
 
 	assert.NotNil(t, err)
 }
+
+// blockingController signals on started when it begins handling an add and then blocks until release is closed.
+type blockingController struct {
+	printctlr.Controller
+	started chan string
+	release chan struct{}
+}
+
+func (c blockingController) ResourceAdded(r *unstructured.Unstructured) {
+	c.started <- r.GetName()
+	<-c.release
+}
+
+func TestShutdownWaitsForInFlightEvents(t *testing.T) {
+	con := blockingController{started: make(chan string, 10), release: make(chan struct{})}
+	cw := &CRWatcher{Config: &Config{}}
+	cw.setupHandler(con)
+	r := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "Thing1",
+			},
+		},
+	}
+
+	handled := make(chan struct{})
+	go func() {
+		cw.handler.OnAdd(r)
+		close(handled)
+	}()
+	assert.Equal(t, "Thing1", receive(t, con.started, "the add to start"))
+
+	err := cw.Shutdown(10 * time.Millisecond)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "timed out after 10ms waiting for in-flight events to finish", err.Error())
+	}
+
+	close(con.release)
+	assert.Nil(t, cw.Shutdown(5*time.Second))
+	<-handled
+}
+
+func TestShutdownDropsNewEvents(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRC := NewMockResourceController(mockCtrl)
+	cw := &CRWatcher{Config: &Config{}}
+	cw.setupHandler(mockRC)
+	r := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "Thing1",
+			},
+		},
+	}
+
+	assert.Nil(t, cw.Shutdown(time.Second))
+
+	mockRC.EXPECT().ResourceAdded(r).MinTimes(0).MaxTimes(0)
+	mockRC.EXPECT().ResourceUpdated(r, r).MinTimes(0).MaxTimes(0)
+	mockRC.EXPECT().ResourceDeleted(r).MinTimes(0).MaxTimes(0)
+
+	cw.handler.OnAdd(r)
+	cw.handler.OnUpdate(r, r)
+	cw.handler.OnDelete(r)
+}
+
+func TestHealthy(t *testing.T) {
+	res := &fakeResource{}
+	cw := &CRWatcher{Config: &Config{}, resource: res}
+	assert.Nil(t, cw.Healthy())
+	assert.Equal(t, int64(1), res.listOpts.Limit)
+
+	res.listErr = errors.New("the server could not find the requested resource")
+	err := cw.Healthy()
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "the server could not find the requested resource", err.Error())
+	}
+}
@@ -16,6 +16,7 @@ package helmctlr
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/ghodss/yaml"
@@ -28,12 +29,15 @@ import (
 
 var defaultNS = "default"
 
+// podNamespaceEnv holds the namespace lostromos runs in, usually set with the downward API.
+const podNamespaceEnv = "POD_NAMESPACE"
+
 // Controller is a crwatcher.ResourceController that works with Helm to deploy
 // helm charts into K8s providing a CustomResource as value data to the charts
 type Controller struct {
 	ChartDir    string         // path to dir where the Helm chart is located
 	Helm        helm.Interface // Helm for talking with helm
-	Namespace   string         // Default namespace to deploy into. If empty it will default to "default"
+	Namespace   string         // Default namespace to deploy into. If empty it will default to $POD_NAMESPACE, then "default"
 	ReleaseName string         // Prefix for the helm release name. Will look like ReleaseName-CR_Name
 	Wait        bool           // Whether or not to wait for resources during Update and Install before marking a release successful
 	WaitTimeout int64          // time in seconds to wait for kubernetes resources to be created before marking a release successful
@@ -46,6 +50,9 @@ func NewController(chartDir, ns, rn, host string, wait bool, waitto int64, logge
 		// If you don't give us a logger, set logger to a nop logger
 		logger = zap.NewNop().Sugar()
 	}
+	if ns == "" {
+		ns = os.Getenv(podNamespaceEnv)
+	}
 	if ns == "" {
 		ns = defaultNS
 	}
@@ -16,6 +16,7 @@ package helmctlr_test
 
 import (
 	"errors"
+	"os"
 	"testing"
 	"time"
 
@@ -141,7 +142,22 @@ func lessThan(a float64, b float64) bool {
 	return a < b
 }
 
+// unsetPodNamespace clears POD_NAMESPACE for a test and returns a func restoring its previous value.
+func unsetPodNamespace(t *testing.T) func() {
+	old, set := os.LookupEnv("POD_NAMESPACE")
+	assert.Nil(t, os.Unsetenv("POD_NAMESPACE"))
+	return func() {
+		if set {
+			os.Setenv("POD_NAMESPACE", old)
+			return
+		}
+		os.Unsetenv("POD_NAMESPACE")
+	}
+}
+
 func TestNewControllerSetsNS(t *testing.T) {
+	defer unsetPodNamespace(t)()
+
 	c := helmctlr.NewController("chartDir", "", "release", "127.0.0.3:4321", false, 120, nil)
 	assert.Equal(t, "default", c.Namespace, "Namespace should be set to 'default' when not provided")
 	assert.Equal(t, "chartDir", c.ChartDir)
@@ -151,6 +167,17 @@ func TestNewControllerSetsNS(t *testing.T) {
 	assert.Equal(t, "my_ns", c.Namespace, "Namespace should be set to the value provided")
 }
 
+func TestNewControllerSetsNSFromPodNamespace(t *testing.T) {
+	defer unsetPodNamespace(t)()
+	assert.Nil(t, os.Setenv("POD_NAMESPACE", "pod_ns"))
+
+	c := helmctlr.NewController("chartDir", "", "release", "127.0.0.3:4321", false, 120, nil)
+	assert.Equal(t, "pod_ns", c.Namespace, "Namespace should be set to POD_NAMESPACE when not provided")
+
+	c = helmctlr.NewController("chartDir", "my_ns", "release", "127.0.0.3:4321", false, 120, nil)
+	assert.Equal(t, "my_ns", c.Namespace, "Namespace should be set to the value provided over POD_NAMESPACE")
+}
+
 func TestResourceAddedHappyPath(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
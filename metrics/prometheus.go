@@ -90,6 +90,13 @@ var (
 		Namespace: "releases",
 	})
 
+	// ReconcilePanics is a metric for the number of panics recovered while a controller handled an event
+	ReconcilePanics = prometheus.NewCounter(prometheus.CounterOpts{
+		Help:      "The number of panics recovered while handling events",
+		Name:      "panic_total",
+		Namespace: "releases",
+	})
+
 	// TotalEvents is a metric for the number of events that have been handled by this operator
 	TotalEvents = prometheus.NewCounter(prometheus.CounterOpts{
 		Help:      "The number of events (create/delete/updates) processed by this operator",
@@ -109,5 +116,6 @@ func init() {
 	prometheus.MustRegister(UpdatedReleases)
 	prometheus.MustRegister(UpdateFailures)
 	prometheus.MustRegister(LastSuccessfulUpdate)
+	prometheus.MustRegister(ReconcilePanics)
 	prometheus.MustRegister(TotalEvents)
 }
@@ -15,22 +15,31 @@
 package status
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 )
 
 // Response used to define the status response for Lostromos
 type Response struct {
-	Success bool
-	Info    string
+	Success bool   `json:"success"`
+	Info    string `json:"info,omitempty"`
 }
 
-// Handler is used for managing calls to /status to inform of the current status of Lostromos.
-func Handler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	_, err := fmt.Fprint(w, "{\"success\": true}")
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+// NewHandler returns a handler for calls to /status to inform of the current status of Lostromos. healthy is called on
+// every request; if it returns an error the handler responds with a 503 and the error as the info.
+func NewHandler(healthy func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := healthy(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(Response{Success: false, Info: err.Error()})
+			return
+		}
+		_, err := fmt.Fprint(w, "{\"success\": true}")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
 }
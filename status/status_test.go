@@ -15,6 +15,7 @@
 package status
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,6 +24,14 @@ import (
 
 func TestStatusHandler(t *testing.T) {
 	writer := new(http.TestResponseWriter)
-	Handler(writer, nil)
+	NewHandler(func() error { return nil })(writer, nil)
 	assert.Equal(t, "{\"success\": true}", writer.Output)
 }
+
+func TestStatusHandlerUnhealthy(t *testing.T) {
+	writer := new(http.TestResponseWriter)
+	NewHandler(func() error { return errors.New("connection refused") })(writer, nil)
+	assert.Equal(t, 503, writer.StatusCode)
+	assert.Equal(t, "application/json", writer.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"success\":false,\"info\":\"connection refused\"}\n", writer.Output)
+}